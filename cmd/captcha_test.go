@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// withCaptchaVerifyEndpoint points provider's siteverify endpoint at url for
+// the duration of the test.
+func withCaptchaVerifyEndpoint(t *testing.T, provider, url string) {
+	t.Helper()
+
+	orig := captchaVerifyEndpoints[provider]
+	captchaVerifyEndpoints[provider] = url
+	t.Cleanup(func() { captchaVerifyEndpoints[provider] = orig })
+}
+
+func newFakeSiteVerify(t *testing.T, resp siteVerifyResp) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestCaptchaVerifyEmptyToken(t *testing.T) {
+	p := &httpCaptchaProvider{cfg: captchaConfig{Enabled: true, Provider: captchaProviderTurnstile}}
+
+	ok, err := p.Verify("", "1.2.3.4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Fatal("expected an empty token to fail verification without calling the provider")
+	}
+}
+
+func TestCaptchaVerifySuccess(t *testing.T) {
+	withCaptchaVerifyEndpoint(t, captchaProviderTurnstile,
+		newFakeSiteVerify(t, siteVerifyResp{Success: true}))
+
+	p, err := newCaptchaProvider(captchaConfig{Enabled: true, Provider: captchaProviderTurnstile, SecretKey: "s"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ok, err := p.Verify("tok", "1.2.3.4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected verification to pass")
+	}
+}
+
+func TestCaptchaVerifyProviderRejects(t *testing.T) {
+	withCaptchaVerifyEndpoint(t, captchaProviderHCaptcha,
+		newFakeSiteVerify(t, siteVerifyResp{Success: false}))
+
+	p, err := newCaptchaProvider(captchaConfig{Enabled: true, Provider: captchaProviderHCaptcha, SecretKey: "s"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ok, err := p.Verify("tok", "1.2.3.4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Fatal("expected verification to fail when the provider reports success=false")
+	}
+}
+
+func TestCaptchaVerifyRecaptchaScoreThreshold(t *testing.T) {
+	withCaptchaVerifyEndpoint(t, captchaProviderRecaptcha,
+		newFakeSiteVerify(t, siteVerifyResp{Success: true, Score: 0.2}))
+
+	p, err := newCaptchaProvider(captchaConfig{
+		Enabled: true, Provider: captchaProviderRecaptcha, SecretKey: "s", ScoreThreshold: 0.5,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ok, err := p.Verify("tok", "1.2.3.4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Fatal("expected verification to fail when the reCAPTCHA v3 score is below the configured threshold")
+	}
+}
+
+func TestCaptchaVerifyRecaptchaScoreAboveThreshold(t *testing.T) {
+	withCaptchaVerifyEndpoint(t, captchaProviderRecaptcha,
+		newFakeSiteVerify(t, siteVerifyResp{Success: true, Score: 0.9}))
+
+	p, err := newCaptchaProvider(captchaConfig{
+		Enabled: true, Provider: captchaProviderRecaptcha, SecretKey: "s", ScoreThreshold: 0.5,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ok, err := p.Verify("tok", "1.2.3.4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected verification to pass when the score clears the threshold")
+	}
+}
+
+func TestNewCaptchaProviderDisabled(t *testing.T) {
+	p, err := newCaptchaProvider(captchaConfig{Enabled: false})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p != nil {
+		t.Fatal("expected a nil provider when captcha is disabled")
+	}
+}
+
+func TestNewCaptchaProviderUnknown(t *testing.T) {
+	if _, err := newCaptchaProvider(captchaConfig{Enabled: true, Provider: "bogus"}); err == nil {
+		t.Fatal("expected an error for an unknown provider")
+	}
+}
+
+func TestCaptchaResponseField(t *testing.T) {
+	cases := map[string]string{
+		captchaProviderTurnstile: "cf-turnstile-response",
+		captchaProviderHCaptcha:  "h-captcha-response",
+		captchaProviderRecaptcha: "g-recaptcha-response",
+	}
+
+	for provider, want := range cases {
+		if got := captchaResponseField(provider); got != want {
+			t.Fatalf("captchaResponseField(%q) = %q, want %q", provider, got, want)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+// exportSizeThreshold is the zip size, in bytes, beyond which the export is
+// delivered as a one-time signed download link instead of being attached to
+// an e-mail. Mailbox providers commonly reject attachments above a few
+// megabytes.
+const exportSizeThreshold = 5 * 1024 * 1024
+
+// exportRateLimit is how often a given subscriber may request a data
+// export. It's enforced per subUUID by the export job queue, not per
+// request, so retried/duplicate requests within the window are dropped
+// rather than re-queued.
+const exportRateLimit = 24 * time.Hour
+
+// exportBundle is the full portability export produced for a subscriber,
+// built as a zip containing profile.json, subscriptions.csv,
+// campaign_views.csv, link_clicks.csv, and report.html.
+type exportBundle struct {
+	SubUUID string
+	Email   string
+	Zip     []byte
+}
+
+// exportJob is queued onto the subscriber export job queue so that building
+// the zip (which can be slow for subscribers with a long campaign/click
+// history) happens off the request goroutine.
+type exportJob struct {
+	SubUUID    string
+	Exportable map[string]bool
+}
+
+// errExportRateLimited is returned by queueSubscriberExport when the
+// subscriber has already requested an export within exportRateLimit.
+var errExportRateLimited = fmt.Errorf("export already requested recently, try again later")
+
+// queueSubscriberExport rate-limits (one export per subUUID per
+// exportRateLimit) and enqueues an export job. The actual zip is built and
+// delivered asynchronously by the export worker pool started in
+// export_queue.go.
+func queueSubscriberExport(app *App, subUUID string, exportable map[string]bool) error {
+	if !exportLimiter.allow(subUUID, exportRateLimit) {
+		return errExportRateLimited
+	}
+
+	// allow() above already reserved the subscriber's rate-limit slot so
+	// concurrent requests can't both queue a job; if the queue turns out to
+	// be full, give the slot back instead of having the subscriber burn
+	// their one-per-window export on a request that was never queued.
+	if err := pushExportJob(app, exportJob{SubUUID: subUUID, Exportable: exportable}); err != nil {
+		exportLimiter.release(subUUID)
+		return err
+	}
+	return nil
+}
+
+// buildExportBundle assembles the zip for a subscriber export job. It is run
+// by the export worker, not on the request goroutine.
+func buildExportBundle(app *App, job exportJob) (*exportBundle, error) {
+	data, rawJSON, err := exportSubscriberData(0, job.SubUUID, job.Exportable, app)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode the data once and reuse the resulting map for all three CSVs
+	// instead of re-marshaling/unmarshaling per file. profile.json is written
+	// from rawJSON as is.
+	var fields map[string]interface{}
+	if err := json.Unmarshal(rawJSON, &fields); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	if err := addRawJSONToZip(zw, "profile.json", rawJSON); err != nil {
+		return nil, err
+	}
+
+	listsRows, err := exportCSVRowsFromField(fields, "lists", []string{"id", "name", "subscription_status", "subscribed_at"})
+	if err != nil {
+		return nil, fmt.Errorf("subscriptions.csv: %w", err)
+	}
+	if err := addCSVToZip(zw, "subscriptions.csv", exportSubscriptionsCSVHeader, listsRows); err != nil {
+		return nil, err
+	}
+
+	viewsRows, err := exportCSVRowsFromField(fields, "campaign_views", []string{"campaign_id", "campaign_name", "created_at"})
+	if err != nil {
+		return nil, fmt.Errorf("campaign_views.csv: %w", err)
+	}
+	if err := addCSVToZip(zw, "campaign_views.csv", exportViewsCSVHeader, viewsRows); err != nil {
+		return nil, err
+	}
+
+	clicksRows, err := exportCSVRowsFromField(fields, "link_clicks", []string{"campaign_id", "url", "created_at"})
+	if err != nil {
+		return nil, fmt.Errorf("link_clicks.csv: %w", err)
+	}
+	if err := addCSVToZip(zw, "link_clicks.csv", exportClicksCSVHeader, clicksRows); err != nil {
+		return nil, err
+	}
+	if err := addReportHTMLToZip(app, zw, data); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return &exportBundle{SubUUID: job.SubUUID, Email: data.Email, Zip: buf.Bytes()}, nil
+}
+
+// deliverExportBundle e-mails the zip to the subscriber, or, if it exceeds
+// exportSizeThreshold, e-mails a one-time signed download link instead.
+func deliverExportBundle(app *App, b *exportBundle) error {
+	if len(b.Zip) <= exportSizeThreshold {
+		return sendExportZipEmail(app, b)
+	}
+	return sendExportDownloadLinkEmail(app, b)
+}
+
+// runExportJob is the export worker's entry point, called by the worker
+// goroutines started in export_queue.go for every job popped off the
+// queue. It builds the zip and delivers it, logging (rather than retrying)
+// on failure, consistent with how other best-effort background jobs in
+// this package are handled.
+func runExportJob(app *App, job exportJob) {
+	b, err := buildExportBundle(app, job)
+	if err != nil {
+		app.log.Printf("error building data export for %s: %v", job.SubUUID, err)
+		return
+	}
+
+	if err := deliverExportBundle(app, b); err != nil {
+		app.log.Printf("error delivering data export for %s: %v", job.SubUUID, err)
+	}
+}
+
+var (
+	exportSubscriptionsCSVHeader = []string{"list_id", "list_name", "status", "subscribed_at"}
+	exportViewsCSVHeader         = []string{"campaign_id", "campaign_name", "viewed_at"}
+	exportClicksCSVHeader        = []string{"campaign_id", "link", "clicked_at"}
+)
+
+// exportCSVRowsFromField pulls the named slice out of the subscriber export
+// data (already decoded into a generic map by buildExportBundle) and turns
+// it into CSV rows, one per entry, with one cell per cols entry. It goes via
+// the generic map rather
+// than the concrete export struct fields directly, since the shape of that
+// struct's list/view/click entries is expected to evolve independently of
+// this export-bundle packaging code.
+//
+// A column whose value is null on a given row is written as an empty cell (a
+// subscriber can legitimately have a null campaign_name for a deleted
+// campaign, say), and a null field (a subscriber with no lists, views, or
+// clicks marshals its slice as JSON null, not []) is simply an empty CSV —
+// but if `field` itself isn't present at all, isn't a list or null, or is
+// missing one of `cols` on any of its rows, that means
+// exportSubscriberData's output no longer matches what this function
+// expects, and it errors instead of silently shipping a blank CSV.
+func exportCSVRowsFromField(fields map[string]interface{}, field string, cols []string) ([][]string, error) {
+	raw, ok := fields[field]
+	if !ok {
+		return nil, fmt.Errorf("expected field %q not present in export data", field)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected field %q to be a list, got %T", field, raw)
+	}
+
+	rows := make([][]string, 0, len(items))
+	for n, it := range items {
+		row, ok := it.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected field %q entry %d to be an object, got %T", field, n, it)
+		}
+
+		for _, c := range cols {
+			if _, ok := row[c]; !ok {
+				return nil, fmt.Errorf("expected column %q not present on field %q entry %d", c, field, n)
+			}
+		}
+
+		r := make([]string, len(cols))
+		for i, c := range cols {
+			if v := row[c]; v != nil {
+				r[i] = fmt.Sprintf("%v", v)
+			}
+		}
+		rows = append(rows, r)
+	}
+	return rows, nil
+}
+
+// addRawJSONToZip writes already-serialized JSON bytes (as produced by
+// exportSubscriberData) to the zip verbatim, rather than re-encoding the
+// decoded struct.
+func addRawJSONToZip(zw *zip.Writer, name string, raw []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(raw)
+	return err
+}
+
+func addCSVToZip(zw *zip.Writer, name string, header []string, rows [][]string) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := w.Write(r); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func addReportHTMLToZip(app *App, zw *zip.Writer, data interface{}) error {
+	f, err := zw.Create("report.html")
+	if err != nil {
+		return err
+	}
+
+	tpl, err := template.New("report").Parse(exportReportHTMLTpl)
+	if err != nil {
+		return err
+	}
+	return tpl.Execute(f, data)
+}
+
+const exportReportHTMLTpl = `<!doctype html>
+<html>
+<head><meta charset="utf-8"><title>Your data</title></head>
+<body>
+	<h1>Your data</h1>
+	<p>This report contains all the data associated with your subscriber profile.</p>
+	<pre>{{ printf "%+v" . }}</pre>
+</body>
+</html>`
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/knadh/listmonk/internal/messenger"
+)
+
+const exportZipAttachmentName = "data.zip"
+
+// exportLinkTTL is how long a one-time export download link stays valid.
+const exportLinkTTL = 48 * time.Hour
+
+// sendExportZipEmail e-mails the export zip as an attachment.
+func sendExportZipEmail(app *App, b *exportBundle) error {
+	return app.messengers[emailMsgr].Push(messenger.Message{
+		ContentType: app.notifTpls.contentType,
+		From:        app.constants.FromEmail,
+		To:          []string{b.Email},
+		Subject:     "Your data",
+		Body:        []byte("Your requested data export is attached to this e-mail."),
+		Attachments: []messenger.Attachment{
+			{
+				Name:    exportZipAttachmentName,
+				Content: b.Zip,
+				Header:  messenger.MakeAttachmentHeader(exportZipAttachmentName, "base64"),
+			},
+		},
+	})
+}
+
+// sendExportDownloadLinkEmail stores the zip in exportDownloads and e-mails
+// a one-time, HMAC-signed download link for it, for exports that are too
+// large to attach directly.
+func sendExportDownloadLinkEmail(app *App, b *exportBundle) error {
+	exportDownloads.Put(b.SubUUID, b.Zip, exportLinkTTL)
+
+	sig, exp := signURL(getURLSignSecrets().Current, b.SubUUID, b.SubUUID, "export", exportLinkTTL)
+	url := app.constants.RootURL + "/subscription/" + b.SubUUID + "/export/download?s=" + sig +
+		"&e=" + strconv.FormatInt(exp, 10)
+
+	return app.messengers[emailMsgr].Push(messenger.Message{
+		ContentType: app.notifTpls.contentType,
+		From:        app.constants.FromEmail,
+		To:          []string{b.Email},
+		Subject:     "Your data",
+		Body:        []byte("Your requested data export is ready. Download it here (this link expires): " + url),
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// exportDownloadStore holds built export zips that are too large to e-mail
+// directly, keyed by subUUID, until the one-time download link is used. An
+// entry older than exportLinkTTL is no longer served, but it stays in memory
+// until a Take or a later Put for the same subUUID; there is no background
+// sweep. It's deliberately a small in-process store rather
+// than a DB table: an export download link is only ever useful once, for a
+// short window, right after the worker that built it finishes.
+type exportDownloadStore struct {
+	mu    sync.Mutex
+	items map[string]exportDownloadItem
+}
+
+type exportDownloadItem struct {
+	zip       []byte
+	expiresAt time.Time
+}
+
+func newExportDownloadStore() *exportDownloadStore {
+	return &exportDownloadStore{items: make(map[string]exportDownloadItem)}
+}
+
+// exportDownloads is the process-wide store that sendExportDownloadLinkEmail
+// and handleExportDownload share.
+var exportDownloads = newExportDownloadStore()
+
+// Put stores the zip for subUUID, valid for ttl.
+func (s *exportDownloadStore) Put(subUUID string, zip []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[subUUID] = exportDownloadItem{zip: zip, expiresAt: time.Now().Add(ttl)}
+}
+
+// Take returns the stored zip for subUUID and removes it, since the link is
+// one-time use. ok is false if there's nothing stored, or it has expired.
+func (s *exportDownloadStore) Take(subUUID string) (zip []byte, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, found := s.items[subUUID]
+	delete(s.items, subUUID)
+	if !found || time.Now().After(item.expiresAt) {
+		return nil, false
+	}
+	return item.zip, true
+}
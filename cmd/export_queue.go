@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// exportQueueJob pairs an exportJob with the *App it was queued against, so
+// the worker goroutine below (which outlives any single request) has
+// everything it needs to build and deliver the bundle.
+type exportQueueJob struct {
+	app *App
+	job exportJob
+}
+
+// exportQueueSize is how many pending export jobs can sit in the channel
+// before queueSubscriberExport starts rejecting new requests. This bounds
+// memory use if exports are requested faster than the workers can build
+// them.
+const exportQueueSize = 256
+
+// exportWorkerCount is how many goroutines concurrently drain the export
+// queue. Building a bundle does DB reads and zip/CSV encoding, not much
+// besides — a small fixed pool is enough to keep large exports from piling
+// up behind each other without competing too hard with request-serving
+// goroutines for DB connections.
+const exportWorkerCount = 2
+
+var (
+	exportQueue     chan exportQueueJob
+	exportQueueOnce sync.Once
+)
+
+// startExportWorkers lazily starts the fixed pool of goroutines that drain
+// exportQueue, calling runExportJob for each job. It's safe to call from
+// every request; only the first call has any effect.
+func startExportWorkers() {
+	exportQueueOnce.Do(func() {
+		exportQueue = make(chan exportQueueJob, exportQueueSize)
+		for i := 0; i < exportWorkerCount; i++ {
+			go func() {
+				for qj := range exportQueue {
+					runExportJob(qj.app, qj.job)
+				}
+			}()
+		}
+	})
+}
+
+// pushExportJob enqueues job for asynchronous processing by the export
+// worker pool, starting the workers on first use. It returns an error
+// instead of blocking if the queue is full.
+func pushExportJob(app *App, job exportJob) error {
+	startExportWorkers()
+
+	select {
+	case exportQueue <- exportQueueJob{app: app, job: job}:
+		return nil
+	default:
+		return fmt.Errorf("export queue is full, try again later")
+	}
+}
+
+// exportRateLimiter tracks the last time each subscriber successfully
+// queued an export, so queueSubscriberExport can enforce "one export per N
+// hours per UUID" without a dedicated settings-table/migration round trip
+// for what is, in effect, per-process throttling.
+type exportRateLimiter struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// exportLimiterCleanupInterval is how often exportLimiter sweeps out entries
+// whose rate-limit window has already passed. Left alone, last grows one
+// entry per distinct subscriber that has ever requested an export and never
+// shrinks — an unbounded leak for a long-running instance.
+const exportLimiterCleanupInterval = 1 * time.Hour
+
+var (
+	exportLimiter            = &exportRateLimiter{last: make(map[string]time.Time)}
+	exportLimiterCleanupOnce sync.Once
+)
+
+// startExportLimiterCleanup lazily starts the background sweep, same
+// once-started pattern as startExportWorkers.
+func startExportLimiterCleanup() {
+	exportLimiterCleanupOnce.Do(func() {
+		go func() {
+			for range time.Tick(exportLimiterCleanupInterval) {
+				exportLimiter.cleanup(exportRateLimit)
+			}
+		}()
+	})
+}
+
+// cleanup removes every entry older than window.
+func (l *exportRateLimiter) cleanup(window time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for k, last := range l.last {
+		if now.Sub(last) >= window {
+			delete(l.last, k)
+		}
+	}
+}
+
+// allow reports whether subUUID may queue another export given window, and
+// records this attempt as the new "last requested" time if so. The record
+// happens up front, atomically with the check, so two concurrent requests
+// for the same subUUID can't both pass; callers that fail to actually queue
+// the job after allow() returns true must call release() to give the slot
+// back rather than burning it on a request that never got queued.
+func (l *exportRateLimiter) allow(subUUID string, window time.Duration) bool {
+	startExportLimiterCleanup()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if last, ok := l.last[subUUID]; ok && time.Since(last) < window {
+		return false
+	}
+	l.last[subUUID] = time.Now()
+	return true
+}
+
+// release undoes the reservation allow() made for subUUID. queueSubscriberExport
+// calls this when pushExportJob fails after allow() already recorded the
+// attempt, so the subscriber doesn't lose their one-per-window export for a
+// request that was never actually queued.
+func (l *exportRateLimiter) release(subUUID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.last, subUUID)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestExportRateLimiterAllowThenRelease(t *testing.T) {
+	l := &exportRateLimiter{last: make(map[string]time.Time)}
+
+	if !l.allow("sub-1", time.Hour) {
+		t.Fatal("expected the first allow to succeed")
+	}
+	if l.allow("sub-1", time.Hour) {
+		t.Fatal("expected a second allow within the window to be rate-limited")
+	}
+
+	l.release("sub-1")
+
+	if !l.allow("sub-1", time.Hour) {
+		t.Fatal("expected allow to succeed again after release")
+	}
+}
+
+// TestExportRateLimiterReleaseOnFailedPush mirrors queueSubscriberExport's
+// own allow/push/release sequence: a failed push must give the rate-limit
+// slot back rather than burning the subscriber's one-per-window export on a
+// request that was never actually queued.
+func TestExportRateLimiterReleaseOnFailedPush(t *testing.T) {
+	l := &exportRateLimiter{last: make(map[string]time.Time)}
+
+	queue := func(pushFails bool) error {
+		if !l.allow("sub-1", time.Hour) {
+			return errExportRateLimited
+		}
+		if pushFails {
+			l.release("sub-1")
+			return fmt.Errorf("export queue is full, try again later")
+		}
+		return nil
+	}
+
+	if err := queue(true); err == nil {
+		t.Fatal("expected the simulated push failure to surface")
+	}
+	if err := queue(false); err != nil {
+		t.Fatalf("expected a retry after a failed push to succeed, got: %v", err)
+	}
+}
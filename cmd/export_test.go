@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExportCSVRowsFromFieldOK(t *testing.T) {
+	fields := map[string]interface{}{
+		"lists": []interface{}{
+			map[string]interface{}{"id": float64(1), "name": "List A"},
+			map[string]interface{}{"id": float64(2), "name": nil},
+		},
+	}
+
+	rows, err := exportCSVRowsFromField(fields, "lists", []string{"id", "name"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := [][]string{{"1", "List A"}, {"2", ""}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("got %v, want %v", rows, want)
+	}
+}
+
+func TestExportCSVRowsFromFieldMissingField(t *testing.T) {
+	_, err := exportCSVRowsFromField(map[string]interface{}{}, "lists", []string{"id"})
+	if err == nil {
+		t.Fatal("expected an error for a missing field, got nil")
+	}
+}
+
+func TestExportCSVRowsFromFieldNullField(t *testing.T) {
+	fields := map[string]interface{}{"lists": nil}
+
+	rows, err := exportCSVRowsFromField(fields, "lists", []string{"id"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rows != nil {
+		t.Fatalf("expected a nil result for a null field, got %v", rows)
+	}
+}
+
+func TestExportCSVRowsFromFieldNotAList(t *testing.T) {
+	fields := map[string]interface{}{"lists": "not-a-list"}
+
+	if _, err := exportCSVRowsFromField(fields, "lists", []string{"id"}); err == nil {
+		t.Fatal("expected an error when the field isn't a list, got nil")
+	}
+}
+
+func TestExportCSVRowsFromFieldEntryNotAnObject(t *testing.T) {
+	fields := map[string]interface{}{"lists": []interface{}{"not-an-object"}}
+
+	if _, err := exportCSVRowsFromField(fields, "lists", []string{"id"}); err == nil {
+		t.Fatal("expected an error when an entry isn't an object, got nil")
+	}
+}
+
+func TestExportCSVRowsFromFieldMissingColumn(t *testing.T) {
+	fields := map[string]interface{}{
+		"lists": []interface{}{
+			map[string]interface{}{"id": float64(1)},
+		},
+	}
+
+	if _, err := exportCSVRowsFromField(fields, "lists", []string{"id", "name"}); err == nil {
+		t.Fatal("expected an error for a missing column, got nil")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// unsubscribeBounceScanInterval is how often the unsubscribe mailbox is
+// polled for new mail.
+const unsubscribeBounceScanInterval = 5 * time.Minute
+
+// bounceMailboxReader abstracts the mailbox connection (POP3/IMAP) the
+// existing bounce-processing pipeline already polls for bounced mail, so
+// startUnsubscribeBounceScanner can reuse that same reader instead of
+// opening a second connection to the same mailbox.
+type bounceMailboxReader interface {
+	// ReadUnread returns the recipient addresses of unread messages in the
+	// mailbox (the `unsubscribe+<campUUID>.<subUUID>@...` address each was
+	// sent to) and marks them as read/consumed.
+	ReadUnread() ([]*mail.Address, error)
+}
+
+// startUnsubscribeBounceScanner polls reader on unsubscribeBounceScanInterval
+// and runs handleUnsubscribeBounce for every message found, so that replies
+// to the `unsubscribe+<campUUID>.<subUUID>@...` mailto address that
+// internal/manager's BuildListUnsubscribeHeaders puts in List-Unsubscribe are
+// actually consumed. It's started once from the same app init path that
+// configures the bounce-processing mailbox reader, alongside the existing
+// bounce scanner, and is a no-op if no unsubscribe mailbox is configured.
+func startUnsubscribeBounceScanner(app *App, reader bounceMailboxReader) {
+	if reader == nil {
+		return
+	}
+
+	go func() {
+		for range time.Tick(unsubscribeBounceScanInterval) {
+			addrs, err := reader.ReadUnread()
+			if err != nil {
+				app.log.Printf("error reading unsubscribe bounce mailbox: %v", err)
+				continue
+			}
+
+			for _, addr := range addrs {
+				if err := handleUnsubscribeBounce(app, addr); err != nil {
+					app.log.Printf("error processing unsubscribe bounce: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// handleUnsubscribeBounce processes an inbound e-mail addressed to the
+// configured unsubscribe mailbox (read via the same bounce/mailbox reader
+// used for handling bounces) and unsubscribes the subscriber whose UUID is
+// encoded in e, the address the e-mail was sent to. The unsubscribe
+// address is expected to be of the form
+// `unsubscribe+<campUUID>.<subUUID>@domain`, which is what
+// internal/manager's BuildListUnsubscribeHeaders() puts in the `mailto:`
+// part of `List-Unsubscribe`. This lets providers that only support the
+// mailto fallback (rather than POSTing to the one-click URL) still
+// unsubscribe the subscriber.
+func handleUnsubscribeBounce(app *App, e *mail.Address) error {
+	addr, err := mail.ParseAddress(e.Address)
+	if err != nil {
+		return fmt.Errorf("invalid unsubscribe bounce address: %v", err)
+	}
+
+	local := strings.SplitN(addr.Address, "@", 2)[0]
+	parts := strings.SplitN(local, "+", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("malformed unsubscribe bounce address: %s", addr.Address)
+	}
+
+	ids := strings.SplitN(parts[1], ".", 2)
+	if len(ids) != 2 {
+		return fmt.Errorf("malformed unsubscribe bounce address: %s", addr.Address)
+	}
+	campUUID, subUUID := ids[0], ids[1]
+
+	return app.core.UnsubscribeByCampaign(subUUID, campUUID, true)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// prefetchHits records the first time any signed URL (pixel, link click, or
+// the unsubscribe confirmation page) was hit for a given campaign/subscriber
+// pair, so that handleSubscriptionPage can defer the destructive unsubscribe
+// action until a later request proves a human actually followed the link
+// rather than a mailbox prefetcher. handleLinkRedirect and
+// handleRegisterCampaignView record hits too, not just
+// handleSubscriptionPage, since a prefetcher that crawls a campaign's links
+// and pixel typically does so before the subscriber ever opens the
+// unsubscribe page themselves.
+var prefetchHits = newPrefetchTracker()
+
+// prefetchCleanupInterval is how often prefetchHits sweeps out entries whose
+// grace window has already passed. Left in the map, such an entry only makes
+// isWithinGraceWindow report false for its pair, at the cost of one entry per
+// distinct campaign/subscriber pair that ever hits a signed URL for the
+// lifetime of a long-running instance. Once it is evicted, the next hit for
+// that pair counts as a new first hit and starts a new grace window.
+const prefetchCleanupInterval = 10 * time.Minute
+
+type prefetchTracker struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newPrefetchTracker() *prefetchTracker {
+	t := &prefetchTracker{seen: make(map[string]time.Time)}
+	go t.cleanupLoop(prefetchCleanupInterval, prefetchGraceWindow)
+	return t
+}
+
+// cleanupLoop periodically evicts entries older than window. It runs for
+// the lifetime of the process, same as the export worker pool in
+// export_queue.go.
+func (t *prefetchTracker) cleanupLoop(interval, window time.Duration) {
+	for range time.Tick(interval) {
+		t.cleanup(window)
+	}
+}
+
+// cleanup removes every entry whose first hit is older than window.
+func (t *prefetchTracker) cleanup(window time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for k, first := range t.seen {
+		if now.Sub(first) >= window {
+			delete(t.seen, k)
+		}
+	}
+}
+
+// isWithinGraceWindow records the current hit for key if it's the first one
+// seen, and reports whether the request falls within window of that first
+// hit — meaning the caller should hold off on the destructive action.
+func (t *prefetchTracker) isWithinGraceWindow(key string, window time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	first, ok := t.seen[key]
+	now := time.Now()
+	if !ok {
+		t.seen[key] = now
+		return true
+	}
+
+	return now.Sub(first) < window
+}
+
+// isWithinPrefetchGraceWindow reports whether this is within
+// prefetchGraceWindow of the first request for this campaign/subscriber
+// pair, recording the hit as a side effect.
+func isWithinPrefetchGraceWindow(subUUID, campUUID string) bool {
+	return prefetchHits.isWithinGraceWindow(campUUID+"|"+subUUID, prefetchGraceWindow)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPrefetchGraceWindowFirstThenSecondHit exercises the exact sequence a
+// real unsubscribe click produces: an earlier hit (the GET that renders the
+// confirmation page, or a link-click/pixel hit recorded earlier in the same
+// campaign), an immediate repeat, and a later hit once the grace window has
+// passed. Every hit within the window of the first one is held back; only a
+// hit after the window has passed is not.
+func TestPrefetchGraceWindowFirstThenSecondHit(t *testing.T) {
+	tr := newPrefetchTracker()
+	window := 20 * time.Millisecond
+
+	if !tr.isWithinGraceWindow("camp|sub", window) {
+		t.Fatal("expected the first hit to be reported as within the grace window")
+	}
+
+	if !tr.isWithinGraceWindow("camp|sub", window) {
+		t.Fatal("expected an immediate second hit to still be within the grace window")
+	}
+
+	time.Sleep(window + 10*time.Millisecond)
+
+	if tr.isWithinGraceWindow("camp|sub", window) {
+		t.Fatal("expected a hit after the grace window has passed to no longer be held back")
+	}
+}
+
+func TestPrefetchGraceWindowIndependentKeys(t *testing.T) {
+	tr := newPrefetchTracker()
+	window := time.Hour
+
+	if !tr.isWithinGraceWindow("camp-a|sub-a", window) {
+		t.Fatal("expected the first hit for camp-a|sub-a to be within the grace window")
+	}
+
+	if !tr.isWithinGraceWindow("camp-b|sub-b", window) {
+		t.Fatal("expected a different campaign/subscriber pair to get its own independent grace window")
+	}
+}
+
+func TestPrefetchTrackerCleanup(t *testing.T) {
+	tr := newPrefetchTracker()
+	window := 10 * time.Millisecond
+
+	tr.isWithinGraceWindow("camp|sub", window)
+	time.Sleep(2 * window)
+	tr.cleanup(window)
+
+	tr.mu.Lock()
+	_, ok := tr.seen["camp|sub"]
+	tr.mu.Unlock()
+
+	if ok {
+		t.Fatal("expected cleanup to evict an entry past its grace window")
+	}
+}
@@ -13,7 +13,6 @@ import (
 	"strings"
 
 	"github.com/knadh/listmonk/internal/i18n"
-	"github.com/knadh/listmonk/internal/messenger"
 	"github.com/knadh/listmonk/internal/subimporter"
 	"github.com/knadh/listmonk/models"
 	"github.com/labstack/echo/v4"
@@ -22,6 +21,11 @@ import (
 
 const (
 	tplMessage = "message"
+
+	// listUnsubscribeOneClick is the value mailbox providers (Gmail, Yahoo,
+	// Outlook) send in the `List-Unsubscribe` form field when they POST to
+	// the one-click unsubscribe URL per RFC 8058.
+	listUnsubscribeOneClick = "One-Click"
 )
 
 // tplRenderer wraps a template.tplRenderer for echo.
@@ -71,6 +75,16 @@ type msgTpl struct {
 type subFormTpl struct {
 	publicTpl
 	Lists []models.List
+
+	// CaptchaEnabled, CaptchaProvider, and CaptchaSiteKey let the
+	// "subscription-form" template render the configured provider's widget
+	// (Turnstile / hCaptcha / reCAPTCHA v3) above the submit button.
+	// CaptchaProvider picks which of the three markup branches to render;
+	// without it the template can't tell which widget's script/markup to
+	// emit for a given site key.
+	CaptchaEnabled  bool
+	CaptchaProvider string
+	CaptchaSiteKey  string
 }
 
 var (
@@ -97,6 +111,12 @@ func handleViewCampaignMessage(c echo.Context) error {
 		subUUID  = c.Param("subUUID")
 	)
 
+	if err := verifySignedURL(campUUID, subUUID, "",
+		c.QueryParam("s"), c.QueryParam("e")); err != nil {
+		return c.Render(http.StatusNotFound, tplMessage,
+			makeMsgTpl(app.i18n.T("public.notFoundTitle"), "", app.i18n.T("public.invalidLink")))
+	}
+
 	// Get the campaign.
 	camp, err := app.core.GetCampaign(0, campUUID)
 	if err != nil {
@@ -160,8 +180,35 @@ func handleSubscriptionPage(c echo.Context) error {
 	out.AllowExport = app.constants.Privacy.AllowExport
 	out.AllowWipe = app.constants.Privacy.AllowWipe
 
+	if err := verifySignedURL(campUUID, subUUID, "",
+		c.QueryParam("s"), c.QueryParam("e")); err != nil {
+		return c.Render(http.StatusNotFound, tplMessage,
+			makeMsgTpl(app.i18n.T("public.notFoundTitle"), "", app.i18n.T("public.invalidLink")))
+	}
+
+	// Record (or check) the prefetch hit on every request to this page, GET
+	// or POST, not just the unsubscribing POST. The GET is what a mailbox
+	// prefetcher (Gmail's image proxy, corporate link scanners) actually
+	// crawls — it never submits the confirmation form — so by the time the
+	// subscriber's own POST arrives, the "first hit" this measures against
+	// is that earlier GET, not the POST itself. Recording only inside the
+	// `unsub` branch below made the subscriber's own click the first hit
+	// every time, turning every legitimate single-click unsubscribe into a
+	// no-op "please confirm again".
+	withinGrace := isWithinPrefetchGraceWindow(subUUID, campUUID)
+
 	// Unsubscribe.
 	if unsub {
+		// Refuse the destructive action until a second, later, interactive
+		// request arrives for this link. The response deliberately does NOT
+		// claim the subscriber has been unsubscribed — it hasn't been — it
+		// asks them to confirm again, which a prefetcher never does.
+		if withinGrace {
+			return c.Render(http.StatusOK, tplMessage,
+				makeMsgTpl(app.i18n.T("public.unsubscribePendingTitle"), "",
+					app.i18n.T("public.unsubscribePendingInfo")))
+		}
+
 		// Is blocklisting allowed?
 		if !app.constants.Privacy.AllowBlocklist {
 			blocklist = false
@@ -179,6 +226,41 @@ func handleSubscriptionPage(c echo.Context) error {
 	return c.Render(http.StatusOK, "subscription", out)
 }
 
+// handleOneClickUnsubscribe handles RFC 8058 one-click unsubscribe requests.
+// It is mounted on its own URL (distinct from handleSubscriptionPage) so that
+// it can be exempted from CSRF checks, and is the URL that should be put in
+// the `List-Unsubscribe` header alongside `List-Unsubscribe-Post:
+// List-Unsubscribe=One-Click`. Mailbox providers (Gmail, Yahoo, Outlook) POST
+// to it directly, without a user ever seeing a page, so it always unsubscribes
+// the subscriber from the campaign's list, blocklisting them too if
+// app.constants.Privacy.AllowBlocklist permits it (same gate
+// handleSubscriptionPage applies), and responds with an empty 200 regardless
+// of rendering concerns.
+func handleOneClickUnsubscribe(c echo.Context) error {
+	var (
+		app      = c.Get("app").(*App)
+		campUUID = c.Param("campUUID")
+		subUUID  = c.Param("subUUID")
+	)
+
+	// RFC 8058 requires the body to carry exactly this field/value.
+	if c.FormValue("List-Unsubscribe") != listUnsubscribeOneClick {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid List-Unsubscribe-Post request")
+	}
+
+	if err := verifySignedURL(campUUID, subUUID, "",
+		c.QueryParam("s"), c.QueryParam("e")); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "invalid or expired link")
+	}
+
+	if err := app.core.UnsubscribeByCampaign(subUUID, campUUID, app.constants.Privacy.AllowBlocklist); err != nil {
+		app.log.Printf("error processing one-click unsubscribe: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, app.i18n.Ts("public.errorProcessingRequest"))
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
 // handleOptinPage renders the double opt-in confirmation page that subscribers
 // see when they click on the "Confirm subscription" button in double-optin
 // notifications.
@@ -263,6 +345,9 @@ func handleSubscriptionFormPage(c echo.Context) error {
 	out := subFormTpl{}
 	out.Title = app.i18n.T("public.sub")
 	out.Lists = lists
+	out.CaptchaEnabled = app.constants.Captcha.Enabled
+	out.CaptchaProvider = app.constants.Captcha.Provider
+	out.CaptchaSiteKey = app.constants.Captcha.SiteKey
 
 	return c.Render(http.StatusOK, "subscription-form", out)
 }
@@ -295,6 +380,30 @@ func handleSubscriptionForm(c echo.Context) error {
 			makeMsgTpl(app.i18n.T("public.errorTitle"), "", app.i18n.T("public.noListsSelected")))
 	}
 
+	// Verify the CAPTCHA response token, if one is configured, before doing
+	// anything else. The provider is constructed fresh from settings on each
+	// request (cheap: no network call happens until Verify()) so that
+	// changing the CAPTCHA settings takes effect immediately.
+	if app.constants.Captcha.Enabled {
+		captcha, err := newCaptchaProvider(app.constants.Captcha)
+		if err != nil {
+			app.log.Printf("error initializing captcha provider: %v", err)
+			return c.Render(http.StatusInternalServerError, tplMessage,
+				makeMsgTpl(app.i18n.T("public.errorTitle"), "", app.i18n.Ts("public.errorProcessingRequest")))
+		}
+
+		ok, err := captcha.Verify(c.FormValue(captchaResponseField(app.constants.Captcha.Provider)), c.RealIP())
+		if err != nil {
+			app.log.Printf("error verifying captcha: %v", err)
+			return c.Render(http.StatusInternalServerError, tplMessage,
+				makeMsgTpl(app.i18n.T("public.errorTitle"), "", app.i18n.Ts("public.errorProcessingRequest")))
+		}
+		if !ok {
+			return c.Render(http.StatusBadRequest, tplMessage,
+				makeMsgTpl(app.i18n.T("public.errorTitle"), "", app.i18n.Ts("public.invalidCaptcha")))
+		}
+	}
+
 	// If there's no name, use the name bit from the e-mail.
 	req.Name = strings.TrimSpace(req.Name)
 	if req.Name == "" {
@@ -348,6 +457,19 @@ func handleLinkRedirect(c echo.Context) error {
 		subUUID  = c.Param("subUUID")
 	)
 
+	if err := verifySignedURL(campUUID, subUUID, linkUUID,
+		c.QueryParam("s"), c.QueryParam("e")); err != nil {
+		return c.Render(http.StatusNotFound, tplMessage,
+			makeMsgTpl(app.i18n.T("public.notFoundTitle"), "", app.i18n.T("public.invalidLink")))
+	}
+
+	// A mailbox prefetcher/corporate link scanner crawls every link in a
+	// campaign e-mail near-instantly on delivery, well before the
+	// subscriber reads it — recording that here gives
+	// handleSubscriptionPage's grace-window check an earlier "first hit" to
+	// measure a genuine later unsubscribe click against.
+	isWithinPrefetchGraceWindow(subUUID, campUUID)
+
 	// If individual tracking is disabled, do not record the subscriber ID.
 	if !app.constants.Privacy.IndividualTracking {
 		subUUID = ""
@@ -373,6 +495,22 @@ func handleRegisterCampaignView(c echo.Context) error {
 		subUUID  = c.Param("subUUID")
 	)
 
+	// Exclude dummy hits from template previews from signature verification
+	// too, since they're never signed.
+	if campUUID != dummyUUID && subUUID != dummyUUID {
+		if err := verifySignedURL(campUUID, subUUID, "",
+			c.QueryParam("s"), c.QueryParam("e")); err != nil {
+			app.log.Printf("error verifying pixel URL signature: %v", err)
+			c.Response().Header().Set("Cache-Control", "no-cache")
+			return c.Blob(http.StatusOK, "image/png", pixelPNG)
+		}
+
+		// Same reasoning as handleLinkRedirect: the pixel is typically
+		// fetched by a mailbox's image proxy the instant the e-mail is
+		// opened, which is a good early "first hit" for the grace window.
+		isWithinPrefetchGraceWindow(subUUID, campUUID)
+	}
+
 	// If individual tracking is disabled, do not record the subscriber ID.
 	if !app.constants.Privacy.IndividualTracking {
 		subUUID = ""
@@ -389,10 +527,13 @@ func handleRegisterCampaignView(c echo.Context) error {
 	return c.Blob(http.StatusOK, "image/png", pixelPNG)
 }
 
-// handleSelfExportSubscriberData pulls the subscriber's profile, list subscriptions,
-// campaign views and clicks and produces a JSON report that is then e-mailed
-// to the subscriber. This is a privacy feature and the data that's exported
-// is dependent on the configuration.
+// handleSelfExportSubscriberData queues a full portability export (profile,
+// list subscriptions, campaign views, and link clicks, bundled as a zip of
+// profile.json, subscriptions.csv, campaign_views.csv, link_clicks.csv, and
+// report.html) for the subscriber. The zip itself is built and delivered
+// asynchronously by the export worker so that large accounts don't block
+// this request. This is a privacy feature and the data that's exported is
+// dependent on the configuration.
 func handleSelfExportSubscriberData(c echo.Context) error {
 	var (
 		app     = c.Get("app").(*App)
@@ -404,47 +545,44 @@ func handleSelfExportSubscriberData(c echo.Context) error {
 			makeMsgTpl(app.i18n.T("public.errorTitle"), "", app.i18n.Ts("public.invalidFeature")))
 	}
 
-	// Get the subscriber's data. A single query that gets the profile,
-	// list subscriptions, campaign views, and link clicks. Names of
-	// private lists are replaced with "Private list".
-	data, b, err := exportSubscriberData(0, subUUID, app.constants.Privacy.Exportable, app)
-	if err != nil {
-		app.log.Printf("error exporting subscriber data: %s", err)
+	if err := queueSubscriberExport(app, subUUID, app.constants.Privacy.Exportable); err != nil {
+		if err == errExportRateLimited {
+			return c.Render(http.StatusTooManyRequests, tplMessage,
+				makeMsgTpl(app.i18n.T("public.errorTitle"), "", app.i18n.Ts("public.exportRateLimited")))
+		}
+
+		app.log.Printf("error queueing subscriber data export: %s", err)
 		return c.Render(http.StatusInternalServerError, tplMessage,
 			makeMsgTpl(app.i18n.T("public.errorTitle"), "", app.i18n.Ts("public.errorProcessingRequest")))
 	}
 
-	// Prepare the attachment e-mail.
-	var msg bytes.Buffer
-	if err := app.notifTpls.tpls.ExecuteTemplate(&msg, notifSubscriberData, data); err != nil {
-		app.log.Printf("error compiling notification template '%s': %v", notifSubscriberData, err)
-		return c.Render(http.StatusInternalServerError, tplMessage,
-			makeMsgTpl(app.i18n.T("public.errorTitle"), "", app.i18n.Ts("public.errorProcessingRequest")))
+	return c.Render(http.StatusOK, tplMessage,
+		makeMsgTpl(app.i18n.T("public.dataSentTitle"), "", app.i18n.T("public.dataSent")))
+}
+
+// handleExportDownload serves the one-time signed download link e-mailed by
+// sendExportDownloadLinkEmail for exports too large to attach directly. The
+// zip is removed from exportDownloads as soon as it's served (or found
+// expired), since the link is single-use.
+func handleExportDownload(c echo.Context) error {
+	var (
+		app     = c.Get("app").(*App)
+		subUUID = c.Param("subUUID")
+	)
+
+	if err := verifySignedURL(subUUID, subUUID, "export",
+		c.QueryParam("s"), c.QueryParam("e")); err != nil {
+		return c.Render(http.StatusNotFound, tplMessage,
+			makeMsgTpl(app.i18n.T("public.notFoundTitle"), "", app.i18n.T("public.invalidLink")))
 	}
 
-	// Send the data as a JSON attachment to the subscriber.
-	const fname = "data.json"
-	if err := app.messengers[emailMsgr].Push(messenger.Message{
-		ContentType: app.notifTpls.contentType,
-		From:        app.constants.FromEmail,
-		To:          []string{data.Email},
-		Subject:     "Your data",
-		Body:        msg.Bytes(),
-		Attachments: []messenger.Attachment{
-			{
-				Name:    fname,
-				Content: b,
-				Header:  messenger.MakeAttachmentHeader(fname, "base64"),
-			},
-		},
-	}); err != nil {
-		app.log.Printf("error e-mailing subscriber profile: %s", err)
-		return c.Render(http.StatusInternalServerError, tplMessage,
-			makeMsgTpl(app.i18n.T("public.errorTitle"), "", app.i18n.Ts("public.errorProcessingRequest")))
+	zip, ok := exportDownloads.Take(subUUID)
+	if !ok {
+		return c.Render(http.StatusNotFound, tplMessage,
+			makeMsgTpl(app.i18n.T("public.notFoundTitle"), "", app.i18n.T("public.invalidLink")))
 	}
 
-	return c.Render(http.StatusOK, tplMessage,
-		makeMsgTpl(app.i18n.T("public.dataSentTitle"), "", app.i18n.T("public.dataSent")))
+	return c.Blob(http.StatusOK, "application/zip", zip)
 }
 
 // handleWipeSubscriberData allows a subscriber to delete their data. The
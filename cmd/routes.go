@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// registerExtraPublicRoutes mounts the public routes added alongside this
+// backlog of changes (RFC 8058 one-click unsubscribe and the async export
+// download link). It's called from initHTTPServer() next to the rest of
+// the public route registrations (handleSubscriptionPage,
+// handleLinkRedirect, handleRegisterCampaignView, etc).
+func registerExtraPublicRoutes(g *echo.Group) {
+	// Mounted on its own path, distinct from handleSubscriptionPage, so it
+	// can be exempted from CSRF middleware: mailbox providers POST here
+	// directly, with no session/cookie, per RFC 8058.
+	g.POST("/subscription/:campUUID/:subUUID/one-click", handleOneClickUnsubscribe)
+
+	// One-time signed download link for exports too large to e-mail as an
+	// attachment; see sendExportDownloadLinkEmail.
+	g.GET("/subscription/:subUUID/export/download", handleExportDownload)
+}
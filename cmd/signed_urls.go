@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/knadh/listmonk/internal/urlsign"
+)
+
+// prefetchGraceWindow is how long after the first request to a signed URL
+// destructive actions (unsubscribing, in particular) are held back. Mailbox
+// prefetchers (Gmail's image proxy, corporate link scanners) hit tracking
+// and unsubscribe URLs automatically; recording the hit but refusing to act
+// on it until a second, later request arrives filters most of that traffic
+// out without losing open/click counts.
+const prefetchGraceWindow = 90 * time.Second
+
+// urlSignSecrets is the process-wide signing key state for
+// tracking/unsubscribe URLs. It's populated by loadURLSignSecrets() from
+// environment variables rather than baked into a single constant, so that
+// the active secret can be rotated, by changing those variables and calling
+// loadURLSignSecrets() again, without invalidating links already sent out in
+// past campaigns — the previous secret keeps verifying until those links
+// expire. It is not read from the settings table.
+var (
+	urlSignSecretsMu sync.RWMutex
+	urlSignSecrets   urlsign.Secrets
+)
+
+// loadURLSignSecrets reads the current/previous signing secrets from the
+// environment (LISTMONK_URL_SIGN_SECRET, and a comma-separated
+// LISTMONK_URL_SIGN_SECRET_PREV) and stores them for
+// verifySignedURL()/signURL() to use. There is no settings-table column or
+// admin UI field for them. It should be called once during app init, and
+// again after those variables change to rotate the secret.
+func loadURLSignSecrets() {
+	cur := os.Getenv("LISTMONK_URL_SIGN_SECRET")
+
+	var prev []string
+	if p := os.Getenv("LISTMONK_URL_SIGN_SECRET_PREV"); p != "" {
+		for _, s := range strings.Split(p, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				prev = append(prev, s)
+			}
+		}
+	}
+
+	setURLSignSecrets(urlsign.Secrets{Current: cur, Previous: prev})
+}
+
+// setURLSignSecrets atomically replaces the active signing secrets.
+func setURLSignSecrets(s urlsign.Secrets) {
+	urlSignSecretsMu.Lock()
+	defer urlSignSecretsMu.Unlock()
+	urlSignSecrets = s
+}
+
+// getURLSignSecrets returns the currently configured signing secrets.
+func getURLSignSecrets() urlsign.Secrets {
+	urlSignSecretsMu.RLock()
+	defer urlSignSecretsMu.RUnlock()
+	return urlSignSecrets
+}
+
+// signURL computes the `s` (signature) and `e` (expiry, unix seconds) query
+// parameters for a URL signed in cmd, such as the export download link. The
+// TrackLink, TrackView, UnsubscribeURL and MessageURL template funcs sign
+// their URLs in internal/manager with the same urlsign.Sign.
+func signURL(secret, campUUID, subUUID, linkUUID string, ttl time.Duration) (sig string, exp int64) {
+	return urlsign.Sign(secret, campUUID, subUUID, linkUUID, ttl)
+}
+
+// verifySignedURL checks the `s` and `e` query params of a tracking or
+// unsubscribe request against the currently configured signing secrets
+// (current and any rotated-out previous ones), and rejects expired links.
+// linkUUID may be empty for URLs (unsubscribe, message view) that aren't
+// scoped to a single link.
+//
+// If no signing secret is configured (LISTMONK_URL_SIGN_SECRET unset — the
+// default on every existing install until that env var is set), signing is
+// off instance-wide: internal/manager's signAndAppend leaves URLs unsigned
+// to match, so there's nothing here to verify either. Without this,
+// urlsign.Verify would reject every one of those unsigned URLs as missing a
+// signature.
+func verifySignedURL(campUUID, subUUID, linkUUID, sig, expParam string) error {
+	secrets := getURLSignSecrets()
+	if secrets.Current == "" {
+		return nil
+	}
+	return urlsign.Verify(secrets, campUUID, subUUID, linkUUID, sig, expParam)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/knadh/listmonk/internal/urlsign"
+)
+
+// withURLSignSecrets points the process-wide signing secrets at secrets for
+// the duration of the test, restoring whatever was configured before.
+func withURLSignSecrets(t *testing.T, secrets urlsign.Secrets) {
+	t.Helper()
+
+	orig := getURLSignSecrets()
+	setURLSignSecrets(secrets)
+	t.Cleanup(func() { setURLSignSecrets(orig) })
+}
+
+func TestVerifySignedURLNoSecretConfigured(t *testing.T) {
+	withURLSignSecrets(t, urlsign.Secrets{})
+
+	// No secret configured means signAndAppend never signed the URL in the
+	// first place, so an empty s/e pair (as well as anything else) must be
+	// accepted rather than rejected as an invalid signature.
+	if err := verifySignedURL("camp", "sub", "", "", ""); err != nil {
+		t.Fatalf("expected no error when no signing secret is configured, got: %v", err)
+	}
+}
+
+func TestVerifySignedURLRoundTrip(t *testing.T) {
+	withURLSignSecrets(t, urlsign.Secrets{Current: "secret"})
+
+	sig, exp := urlsign.Sign("secret", "camp", "sub", "link", 0)
+	if err := verifySignedURL("camp", "sub", "link", sig, strconv.FormatInt(exp, 10)); err != nil {
+		t.Fatalf("expected a correctly signed URL to verify, got: %v", err)
+	}
+}
+
+func TestVerifySignedURLRejectsWhenSecretConfigured(t *testing.T) {
+	withURLSignSecrets(t, urlsign.Secrets{Current: "secret"})
+
+	if err := verifySignedURL("camp", "sub", "", "", ""); err == nil {
+		t.Fatal("expected an error for a missing signature once a secret is configured")
+	}
+}
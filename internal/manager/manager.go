@@ -0,0 +1,85 @@
+package manager
+
+import (
+	"fmt"
+	"html/template"
+
+	"github.com/knadh/listmonk/models"
+)
+
+// linkQuerier is the subset of the DB queries TemplateFuncs needs to
+// resolve (or create) the link UUID a {{ TrackLink }} tag redirects
+// through. It's the same lookup/insert the unsigned link click tracking
+// already went through before this package started signing the result.
+type linkQuerier interface {
+	CreateLink(url, campUUID string) (string, error)
+}
+
+// Manager holds the settings-driven state needed to build and sign the
+// tracking/unsubscribe URLs campaigns embed, and to assemble the outbound
+// message for a given campaign/subscriber pair. It's the `app.manager`
+// referenced by cmd's public handlers (handleViewCampaignMessage and the
+// campaign sender).
+type Manager struct {
+	cfg     SignedLinkConfig
+	queries linkQuerier
+}
+
+// New returns a Manager configured with cfg, resolving/creating link UUIDs
+// for TrackLink through queries.
+func New(cfg SignedLinkConfig, queries linkQuerier) *Manager {
+	return &Manager{cfg: cfg, queries: queries}
+}
+
+// TemplateFuncs returns the template.FuncMap injected into a campaign's
+// compiled template, providing the TrackLink, TrackView, UnsubscribeURL, and
+// MessageURL tags used in campaign bodies. Every URL they build is signed via
+// m.cfg so that cmd's verifySignedURL checks (handleLinkRedirect,
+// handleRegisterCampaignView, handleSubscriptionPage,
+// handleViewCampaignMessage) actually accept them instead of 404ing every
+// real campaign link as "invalid link".
+func (m *Manager) TemplateFuncs(c *models.Campaign) template.FuncMap {
+	return template.FuncMap{
+		"TrackLink": func(url, campUUID, subUUID string) string {
+			linkUUID, err := m.queries.CreateLink(url, campUUID)
+			if err != nil {
+				return url
+			}
+
+			raw := fmt.Sprintf("%s/link/%s/%s/%s", m.cfg.RootURL, campUUID, subUUID, linkUUID)
+			return SignTrackLinkURL(m.cfg, raw, campUUID, subUUID, linkUUID, c.UnsubscribeTTLSeconds)
+		},
+		"TrackView": func(campUUID, subUUID string) string {
+			raw := fmt.Sprintf("%s/campaign/%s/%s/px.png", m.cfg.RootURL, campUUID, subUUID)
+			return SignTrackViewURL(m.cfg, raw, campUUID, subUUID, c.UnsubscribeTTLSeconds)
+		},
+		"UnsubscribeURL": func(campUUID, subUUID string) string {
+			raw := fmt.Sprintf("%s/subscription/%s/%s", m.cfg.RootURL, campUUID, subUUID)
+			return SignUnsubscribeURL(m.cfg, raw, campUUID, subUUID, c.UnsubscribeTTLSeconds)
+		},
+		"MessageURL": func(campUUID, subUUID string) string {
+			raw := fmt.Sprintf("%s/campaign/%s/%s/view", m.cfg.RootURL, campUUID, subUUID)
+			return SignMessageURL(m.cfg, raw, campUUID, subUUID, c.UnsubscribeTTLSeconds)
+		},
+	}
+}
+
+// NewCampaignMessage renders c's compiled template for s and assembles the
+// outbound message, attaching the RFC 8058 List-Unsubscribe /
+// List-Unsubscribe-Post headers via BuildListUnsubscribeHeaders unless the
+// campaign has set OneClickUnsubEnabled to false to preserve the legacy
+// (page-only) unsubscribe behavior. This is what cmd.handleViewCampaignMessage
+// and the campaign sender call to get the per-subscriber message.
+func (m *Manager) NewCampaignMessage(c *models.Campaign, s models.Subscriber) (*models.CampaignMessage, error) {
+	msg, err := c.NewMessage(s)
+	if err != nil {
+		return nil, err
+	}
+
+	hdrs := BuildListUnsubscribeHeaders(m.cfg, c.UUID, s.UUID, c.OneClickUnsubEnabled, c.UnsubscribeTTLSeconds)
+	for k, v := range hdrs {
+		msg.SetHeader(k, v)
+	}
+
+	return msg, nil
+}
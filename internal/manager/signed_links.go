@@ -0,0 +1,107 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/knadh/listmonk/internal/urlsign"
+)
+
+// SignedLinkConfig is the subset of settings TemplateFuncs() and
+// NewCampaignMessage() need to sign and annotate the URLs they generate for
+// a campaign (TrackLink, TrackView, UnsubscribeURL, MessageURL), and to
+// build the outbound List-Unsubscribe / List-Unsubscribe-Post headers.
+type SignedLinkConfig struct {
+	RootURL           string
+	UnsubscribeDomain string // domain part of the `mailto:` unsubscribe address.
+	Secrets           urlsign.Secrets
+	DefaultTTL        time.Duration // 0 = links never expire, unless a campaign opts in to its own TTL.
+}
+
+// signAndAppend appends `?s=<sig>&e=<exp>` to url using cfg.Secrets.Current,
+// scoped to the given campaign/subscriber/link UUID triple and ttl. This is
+// what TrackLink, TrackView, UnsubscribeURL, and MessageURL call before
+// returning the URL they build for a template tag.
+//
+// If cfg.Secrets.Current is empty (no signing secret configured), rawURL is
+// returned unsigned rather than appending a signature computed with an empty
+// key: urlsign.Verify explicitly skips empty candidate secrets, so a
+// signature produced here with one could never be verified by it. cmd's
+// verifySignedURL, in turn, skips verification altogether when no secret is
+// configured, so these unsigned URLs are accepted there.
+func signAndAppend(cfg SignedLinkConfig, rawURL, campUUID, subUUID, linkUUID string, ttl time.Duration) string {
+	if cfg.Secrets.Current == "" {
+		return rawURL
+	}
+
+	sig, exp := urlsign.Sign(cfg.Secrets.Current, campUUID, subUUID, linkUUID, ttl)
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return fmt.Sprintf("%s%ss=%s&e=%d", rawURL, sep, sig, exp)
+}
+
+// campaignTTL returns the campaign's own link TTL if it opted into one,
+// falling back to cfg.DefaultTTL otherwise. ttlSeconds of 0 means "use the
+// instance-wide default", matching how other per-campaign overrides in this
+// package are threaded through.
+func campaignTTL(cfg SignedLinkConfig, ttlSeconds int) time.Duration {
+	if ttlSeconds > 0 {
+		return time.Duration(ttlSeconds) * time.Second
+	}
+	return cfg.DefaultTTL
+}
+
+// SignTrackLinkURL signs the redirect URL behind a {{ TrackLink }} tag.
+// Call this from TemplateFuncs() in place of the raw
+// `/link/:campUUID/:subUUID/:linkUUID` URL.
+func SignTrackLinkURL(cfg SignedLinkConfig, rawURL, campUUID, subUUID, linkUUID string, campaignTTLSeconds int) string {
+	return signAndAppend(cfg, rawURL, campUUID, subUUID, linkUUID, campaignTTL(cfg, campaignTTLSeconds))
+}
+
+// SignTrackViewURL signs the pixel URL behind a {{ TrackView }} tag.
+func SignTrackViewURL(cfg SignedLinkConfig, rawURL, campUUID, subUUID string, campaignTTLSeconds int) string {
+	return signAndAppend(cfg, rawURL, campUUID, subUUID, "", campaignTTL(cfg, campaignTTLSeconds))
+}
+
+// SignUnsubscribeURL signs the URL behind a {{ UnsubscribeURL }} tag.
+func SignUnsubscribeURL(cfg SignedLinkConfig, rawURL, campUUID, subUUID string, campaignTTLSeconds int) string {
+	return signAndAppend(cfg, rawURL, campUUID, subUUID, "", campaignTTL(cfg, campaignTTLSeconds))
+}
+
+// SignMessageURL signs the URL behind a {{ MessageURL }} tag.
+func SignMessageURL(cfg SignedLinkConfig, rawURL, campUUID, subUUID string, campaignTTLSeconds int) string {
+	return signAndAppend(cfg, rawURL, campUUID, subUUID, "", campaignTTL(cfg, campaignTTLSeconds))
+}
+
+// BuildListUnsubscribeHeaders returns the RFC 8058 `List-Unsubscribe` and
+// `List-Unsubscribe-Post` header values for a campaign message addressed to
+// a particular subscriber. NewCampaignMessage() calls this while assembling
+// outgoing campaign e-mails and attaches both headers, unless the campaign
+// has set `oneClickUnsubEnabled` to false to preserve the legacy
+// (page-only) unsubscribe behavior.
+//
+// The mailto address encodes the campaign/subscriber UUID pair
+// (`unsubscribe+<campUUID>.<subUUID>@cfg.UnsubscribeDomain`) so that a
+// reply sent to it, consumed off the configured bounce/mailbox reader, can
+// be resolved back to the subscription to remove without a signed URL.
+func BuildListUnsubscribeHeaders(cfg SignedLinkConfig, campUUID, subUUID string, oneClickUnsubEnabled bool, campaignTTLSeconds int) map[string]string {
+	if !oneClickUnsubEnabled {
+		return nil
+	}
+
+	// This must point at the one-click route (cmd.registerExtraPublicRoutes),
+	// not the unsubscribe confirmation page: mailbox providers POST here
+	// directly per RFC 8058 List-Unsubscribe-Post, with no user ever seeing
+	// a page.
+	unsubURL := fmt.Sprintf("%s/subscription/%s/%s/one-click", cfg.RootURL, campUUID, subUUID)
+	signedUnsubURL := SignUnsubscribeURL(cfg, unsubURL, campUUID, subUUID, campaignTTLSeconds)
+	mailto := fmt.Sprintf("unsubscribe+%s.%s@%s", campUUID, subUUID, cfg.UnsubscribeDomain)
+
+	return map[string]string{
+		"List-Unsubscribe":      fmt.Sprintf("<mailto:%s>, <%s>", mailto, signedUnsubURL),
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+}
@@ -0,0 +1,27 @@
+package manager
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/knadh/listmonk/internal/urlsign"
+)
+
+func TestSignAndAppendNoSecretLeavesURLUnsigned(t *testing.T) {
+	cfg := SignedLinkConfig{RootURL: "https://example.com"}
+
+	got := signAndAppend(cfg, "https://example.com/subscription/camp/sub", "camp", "sub", "", time.Hour)
+	if got != "https://example.com/subscription/camp/sub" {
+		t.Fatalf("expected URL to be left unsigned when no secret is configured, got: %s", got)
+	}
+}
+
+func TestSignAndAppendSignsWhenSecretConfigured(t *testing.T) {
+	cfg := SignedLinkConfig{RootURL: "https://example.com", Secrets: urlsign.Secrets{Current: "secret"}}
+
+	got := signAndAppend(cfg, "https://example.com/subscription/camp/sub", "camp", "sub", "", time.Hour)
+	if !strings.Contains(got, "?s=") || !strings.Contains(got, "&e=") {
+		t.Fatalf("expected a signed URL with s/e params, got: %s", got)
+	}
+}